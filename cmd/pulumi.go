@@ -143,6 +143,10 @@ func NewPulumiCmd() *cobra.Command {
 		"Log to stderr instead of to files")
 	cmd.PersistentFlags().BoolVar(&cmdutil.DisableInteractive, "non-interactive", false,
 		"Disable interactive mode for all commands")
+	cmd.PersistentFlags().BoolVar(&cmdutil.JSONDiagnostics, "json-diagnostics", false,
+		"Emit the CLI's own diagnostics to stderr as newline-delimited JSON")
+	cmd.PersistentFlags().BoolVar(&cmdutil.WarningsAsErrors, "warnings-as-errors", false,
+		"Report the CLI's own warnings as errors and fail the command if any are issued")
 	cmd.PersistentFlags().StringVar(&tracing, "tracing", "",
 		"Emit tracing to a Zipkin-compatible tracing endpoint")
 	cmd.PersistentFlags().StringVar(&profiling, "profiling", "",
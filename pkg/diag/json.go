@@ -0,0 +1,138 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+	"github.com/pulumi/pulumi/pkg/util/logging"
+)
+
+// JSONDiag is the serialized form of a single diagnostic written by a JSON sink.
+type JSONDiag struct {
+	Severity Severity     `json:"severity"`
+	URN      resource.URN `json:"urn,omitempty"`
+	ID       ID           `json:"id,omitempty"`
+	Message  string       `json:"message"`
+	StreamID int32        `json:"streamId,omitempty"`
+}
+
+// JSONSink returns a sink that writes each diagnostic to the given writer as a single line of JSON, so that editors
+// and CI systems can consume diagnostics programmatically.  Colorization options are ignored.
+func JSONSink(w io.Writer, opts FormatOptions) Sink {
+	contract.Require(w != nil, "w")
+	return &jsonSink{opts: opts, w: w}
+}
+
+// jsonSink is a sink which logs output as newline-delimited JSON objects.
+type jsonSink struct {
+	opts FormatOptions // a set of options that control output content.
+	w    io.Writer     // the writer to which diagnostics are emitted.
+	mu   sync.Mutex    // guards writes to w.
+}
+
+func (d *jsonSink) Logf(sev Severity, diag *Diag, args ...interface{}) {
+	switch sev {
+	case Debug:
+		d.Debugf(diag, args...)
+	case Info:
+		d.Infof(diag, args...)
+	case Infoerr:
+		d.Infoerrf(diag, args...)
+	case Warning:
+		d.Warningf(diag, args...)
+	case Error:
+		d.Errorf(diag, args...)
+	default:
+		contract.Failf("Unrecognized severity: %v", sev)
+	}
+}
+
+func (d *jsonSink) Debugf(diag *Diag, args ...interface{}) {
+	logging.V(3).Infof(diag.Message, args...)
+	if d.opts.Debug {
+		d.write(Debug, diag, args...)
+	}
+}
+
+func (d *jsonSink) Infof(diag *Diag, args ...interface{}) {
+	d.write(Info, diag, args...)
+}
+
+func (d *jsonSink) Infoerrf(diag *Diag, args ...interface{}) {
+	d.write(Infoerr, diag, args...)
+}
+
+func (d *jsonSink) Errorf(diag *Diag, args ...interface{}) {
+	d.write(Error, diag, args...)
+}
+
+func (d *jsonSink) Warningf(diag *Diag, args ...interface{}) {
+	if d.opts.WarningsAsErrors {
+		d.Errorf(diag, args...)
+		return
+	}
+	d.write(Warning, diag, args...)
+}
+
+func (d *jsonSink) Stringify(sev Severity, diag *Diag, args ...interface{}) (string, string) {
+	var prefix string
+	if sev != Info && sev != Infoerr {
+		// Unless it's an ordinary stdout message, prepend the message category's prefix (error/warning).
+		switch sev {
+		case Debug, Error, Warning:
+			prefix = string(sev) + ": "
+		default:
+			contract.Failf("Unrecognized diagnostic severity: %v", sev)
+		}
+	}
+	return prefix, d.message(diag, args...) + "\n"
+}
+
+// message formats the diagnostic's message, filtering out any sensitive data we know about.
+func (d *jsonSink) message(diag *Diag, args ...interface{}) string {
+	msg := diag.Message
+	if !diag.Raw {
+		msg = fmt.Sprintf(diag.Message, args...)
+	}
+	return logging.FilterString(msg)
+}
+
+func (d *jsonSink) write(sev Severity, diag *Diag, args ...interface{}) {
+	// Infoerr only selects stderr for the default sink; it is not a distinct severity for consumers of JSON.
+	if sev == Infoerr {
+		sev = Info
+	}
+
+	b, err := json.Marshal(JSONDiag{
+		Severity: sev,
+		URN:      diag.URN,
+		ID:       diag.ID,
+		Message:  d.message(diag, args...),
+		StreamID: diag.StreamID,
+	})
+	contract.AssertNoError(err)
+	b = append(b, '\n')
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = d.w.Write(b)
+	contract.IgnoreError(err)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sync"
 
 	"github.com/pulumi/pulumi/pkg/diag/colors"
 	"github.com/pulumi/pulumi/pkg/util/contract"
@@ -60,6 +61,8 @@ type FormatOptions struct {
 	Pwd   string              // the working directory.
 	Color colors.Colorization // how output should be colorized.
 	Debug bool                // if true, debugging will be output to stdout.
+
+	WarningsAsErrors bool // if true, warnings will be reported as errors.
 }
 
 // DefaultSink returns a default sink that simply logs output to stderr/stdout.
@@ -98,6 +101,7 @@ const DefaultSinkIDPrefix = "PU"
 type defaultSink struct {
 	opts    FormatOptions          // a set of options that control output style and content.
 	writers map[Severity]io.Writer // the writers to use for each kind of diagnostic severity.
+	mu      sync.Mutex             // guards writes to the writers.
 }
 
 func (d *defaultSink) Logf(sev Severity, diag *Diag, args ...interface{}) {
@@ -129,7 +133,7 @@ func (d *defaultSink) Debugf(diag *Diag, args ...interface{}) {
 	if logging.V(9) {
 		logging.V(9).Infof("defaultSink::Debug(%v)", msg[:len(msg)-1])
 	}
-	fmt.Fprint(d.writers[Debug], msg)
+	d.print(Debug, msg)
 }
 
 func (d *defaultSink) Infof(diag *Diag, args ...interface{}) {
@@ -137,7 +141,7 @@ func (d *defaultSink) Infof(diag *Diag, args ...interface{}) {
 	if logging.V(5) {
 		logging.V(5).Infof("defaultSink::Info(%v)", msg[:len(msg)-1])
 	}
-	fmt.Fprint(d.writers[Info], msg)
+	d.print(Info, msg)
 }
 
 func (d *defaultSink) Infoerrf(diag *Diag, args ...interface{}) {
@@ -145,7 +149,7 @@ func (d *defaultSink) Infoerrf(diag *Diag, args ...interface{}) {
 	if logging.V(5) {
 		logging.V(5).Infof("defaultSink::Infoerr(%v)", msg[:len(msg)-1])
 	}
-	fmt.Fprint(d.writers[Infoerr], msg)
+	d.print(Infoerr, msg)
 }
 
 func (d *defaultSink) Errorf(diag *Diag, args ...interface{}) {
@@ -153,15 +157,27 @@ func (d *defaultSink) Errorf(diag *Diag, args ...interface{}) {
 	if logging.V(5) {
 		logging.V(5).Infof("defaultSink::Error(%v)", msg[:len(msg)-1])
 	}
-	fmt.Fprint(d.writers[Error], msg)
+	d.print(Error, msg)
 }
 
 func (d *defaultSink) Warningf(diag *Diag, args ...interface{}) {
+	if d.opts.WarningsAsErrors {
+		d.Errorf(diag, args...)
+		return
+	}
 	msg := d.createMessage(Warning, diag, args...)
 	if logging.V(5) {
 		logging.V(5).Infof("defaultSink::Warning(%v)", msg[:len(msg)-1])
 	}
-	fmt.Fprint(d.writers[Warning], msg)
+	d.print(Warning, msg)
+}
+
+// print writes a fully formatted message to the writer for the given severity.  Sinks are shared by plugins and
+// goroutines throughout the engine, so writes are serialized to keep concurrent messages from interleaving.
+func (d *defaultSink) print(sev Severity, msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fmt.Fprint(d.writers[sev], msg)
 }
 
 func (d *defaultSink) Stringify(sev Severity, diag *Diag, args ...interface{}) (string, string) {
@@ -15,8 +15,14 @@
 package diag
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +71,181 @@ func TestEscape(t *testing.T) {
 	pmiss, smiss := sink.Stringify(Error, Message("", "lots of %v %s %d chars"))
 	assert.Equal(t, "error: lots of %!v(MISSING) %!s(MISSING) %!d(MISSING) chars\n", pmiss+smiss)
 }
+
+func TestJSONSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	sink := JSONSink(&buf, FormatOptions{})
+
+	sink.Debugf(Message("", "not shown"))
+	sink.Infof(Message("urn:pulumi:stack::proj::a:b:c::res", "hello %v"), "world")
+	sink.Infoerrf(Message("", "to stderr"))
+	sink.Warningf(&Diag{ID: 7, Message: "careful"})
+	sink.Errorf(RawMessage("", "100%"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+
+	var diags []JSONDiag
+	for _, line := range lines {
+		var d JSONDiag
+		assert.NoError(t, json.Unmarshal([]byte(line), &d))
+		diags = append(diags, d)
+	}
+	assert.Equal(t, []JSONDiag{
+		{Severity: Info, URN: "urn:pulumi:stack::proj::a:b:c::res", Message: "hello world"},
+		{Severity: Info, Message: "to stderr"},
+		{Severity: Warning, ID: 7, Message: "careful"},
+		{Severity: Error, Message: "100%"},
+	}, diags)
+}
+
+func TestWarningsAsErrors(t *testing.T) {
+	t.Parallel()
+
+	var warnings, errs bytes.Buffer
+	sink := newDefaultSink(FormatOptions{Color: colors.Never, WarningsAsErrors: true}, map[Severity]io.Writer{
+		Debug:   ioutil.Discard,
+		Info:    ioutil.Discard,
+		Infoerr: ioutil.Discard,
+		Error:   &errs,
+		Warning: &warnings,
+	})
+
+	sink.Warningf(Message("", "uh oh: %v"), 42)
+	sink.Logf(Warning, Message("", "again"))
+	assert.Equal(t, "", warnings.String())
+	assert.Equal(t, "error: uh oh: 42\nerror: again\n", errs.String())
+}
+
+func TestJSONWarningsAsErrors(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	sink := JSONSink(&buf, FormatOptions{WarningsAsErrors: true})
+
+	sink.Warningf(&Diag{ID: 7, Message: "careful"})
+
+	var d JSONDiag
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &d))
+	assert.Equal(t, JSONDiag{Severity: Error, ID: 7, Message: "careful"}, d)
+}
+
+func TestJSONSinkStringify(t *testing.T) {
+	t.Parallel()
+
+	sink := JSONSink(ioutil.Discard, FormatOptions{})
+
+	p, s := sink.Stringify(Warning, Message("", "%s"), "lots of %v chars")
+	assert.Equal(t, "warning: lots of %v chars\n", p+s)
+
+	p, s = sink.Stringify(Infoerr, Message("", "plain"))
+	assert.Equal(t, "plain\n", p+s)
+
+	assert.Panics(t, func() {
+		sink.Stringify(Severity("bogus"), Message("", "bad"))
+	})
+}
+
+// byteWriter writes one byte at a time and yields in between, so unsynchronized concurrent writes will interleave.
+type byteWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.mu.Lock()
+		w.buf.WriteByte(b)
+		w.mu.Unlock()
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func (w *byteWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// logConcurrently issues numWorkers*numEach warnings to the sink from numWorkers goroutines.
+func logConcurrently(sink Sink, numWorkers, numEach int) {
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < numEach; j++ {
+				sink.Warningf(Message("", "worker %v message %v"), i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// assertEachMessageOnce checks that every (worker, message) pair issued by logConcurrently appears exactly once.
+func assertEachMessageOnce(t *testing.T, messages []string, numWorkers, numEach int) {
+	seen := make(map[[2]int]int)
+	for _, msg := range messages {
+		var worker, message int
+		n, err := fmt.Sscanf(msg, "worker %d message %d", &worker, &message)
+		if assert.NoError(t, err, msg) && assert.Equal(t, 2, n, msg) {
+			seen[[2]int{worker, message}]++
+		}
+	}
+
+	assert.Len(t, seen, numWorkers*numEach)
+	for i := 0; i < numWorkers; i++ {
+		for j := 0; j < numEach; j++ {
+			assert.Equal(t, 1, seen[[2]int{i, j}], "worker %v message %v", i, j)
+		}
+	}
+}
+
+// TestConcurrentWrites ensures that messages issued from many goroutines are written whole and none are lost.
+func TestConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	buf := &byteWriter{}
+	sink := newDefaultSink(FormatOptions{Color: colors.Never}, map[Severity]io.Writer{
+		Debug:   buf,
+		Info:    buf,
+		Infoerr: buf,
+		Error:   buf,
+		Warning: buf,
+	})
+
+	const numWorkers, numEach = 8, 100
+	logConcurrently(sink, numWorkers, numEach)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if assert.True(t, strings.HasPrefix(line, "warning: "), line) {
+			messages = append(messages, strings.TrimPrefix(line, "warning: "))
+		}
+	}
+	assertEachMessageOnce(t, messages, numWorkers, numEach)
+}
+
+// TestJSONConcurrentWrites ensures that the JSON sink writes one whole object per message when used concurrently.
+func TestJSONConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	buf := &byteWriter{}
+	sink := JSONSink(buf, FormatOptions{})
+
+	const numWorkers, numEach = 8, 100
+	logConcurrently(sink, numWorkers, numEach)
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var d JSONDiag
+		if assert.NoError(t, json.Unmarshal([]byte(line), &d), line) {
+			assert.Equal(t, Warning, d.Severity)
+			messages = append(messages, d.Message)
+		}
+	}
+	assertEachMessageOnce(t, messages, numWorkers, numEach)
+}
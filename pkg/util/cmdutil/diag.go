@@ -15,7 +15,9 @@
 package cmdutil
 
 import (
+	"io"
 	"os"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/pkg/diag"
@@ -25,6 +27,16 @@ import (
 
 var snk diag.Sink
 
+// JSONDiagnostics may be set to true in order to write diagnostics to stderr as newline-delimited JSON rather than
+// human-readable text, so that editors and CI systems can consume them programmatically.
+var JSONDiagnostics bool
+
+// WarningsAsErrors may be set to true in order to report warnings as errors and fail the command if any are issued.
+var WarningsAsErrors bool
+
+// promotedWarnings counts the warnings that have been reported as errors because of WarningsAsErrors.
+var promotedWarnings int32
+
 // By default we'll attempt to figure out if we should have colors or not. This can be overridden
 // for any command by passing --color=... at the command line.
 var globalColorization = colors.Auto
@@ -78,9 +90,10 @@ func SetGlobalColorization(value string) error {
 // Diag lazily allocates a sink to be used if we can't create a compiler.
 func Diag() diag.Sink {
 	if snk == nil {
-		snk = diag.DefaultSink(os.Stdout, os.Stderr, diag.FormatOptions{
-			Color: GetGlobalColorization(),
-		})
+		snk = newDiagSink(os.Stdout, os.Stderr, diag.FormatOptions{
+			Color:            GetGlobalColorization(),
+			WarningsAsErrors: WarningsAsErrors,
+		}, JSONDiagnostics)
 	}
 	return snk
 }
@@ -88,5 +101,47 @@ func Diag() diag.Sink {
 // InitDiag forces initialization of the diagnostics sink with the given options.
 func InitDiag(opts diag.FormatOptions) {
 	contract.Assertf(snk == nil, "Cannot initialize diagnostics sink more than once")
-	snk = diag.DefaultSink(os.Stdout, os.Stderr, opts)
+	if WarningsAsErrors {
+		opts.WarningsAsErrors = true
+	}
+	snk = newDiagSink(os.Stdout, os.Stderr, opts, JSONDiagnostics)
+}
+
+// newDiagSink creates the sink selected by the given options.  JSON diagnostics all go to stderr so that they are
+// never mixed into a command's ordinary output on stdout.
+func newDiagSink(stdout io.Writer, stderr io.Writer, opts diag.FormatOptions, json bool) diag.Sink {
+	var sink diag.Sink
+	if json {
+		sink = diag.JSONSink(stderr, opts)
+	} else {
+		sink = diag.DefaultSink(stdout, stderr, opts)
+	}
+	if opts.WarningsAsErrors {
+		sink = &promotingSink{Sink: sink}
+	}
+	return sink
+}
+
+// promotingSink wraps a sink that reports warnings as errors and records each one, so that the command which issued
+// it can exit with a failure code once it is done.
+type promotingSink struct {
+	diag.Sink
+}
+
+func (s *promotingSink) Logf(sev diag.Severity, d *diag.Diag, args ...interface{}) {
+	if sev == diag.Warning {
+		s.Warningf(d, args...)
+		return
+	}
+	s.Sink.Logf(sev, d, args...)
+}
+
+func (s *promotingSink) Warningf(d *diag.Diag, args ...interface{}) {
+	atomic.AddInt32(&promotedWarnings, 1)
+	s.Sink.Warningf(d, args...)
+}
+
+// warningsPromoted returns true if any warnings have been reported as errors.
+func warningsPromoted() bool {
+	return atomic.LoadInt32(&promotedWarnings) > 0
 }
@@ -0,0 +1,127 @@
+// Copyright 2016-2018, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/diag"
+	"github.com/pulumi/pulumi/pkg/diag/colors"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+func TestNewDiagSinkText(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	sink := newDiagSink(&stdout, &stderr, diag.FormatOptions{Color: colors.Never}, false)
+
+	sink.Infof(diag.Message("", "hello"))
+	sink.Errorf(diag.Message("", "oops"))
+	assert.Equal(t, "hello\n", stdout.String())
+	assert.Equal(t, "error: oops\n", stderr.String())
+}
+
+func TestNewDiagSinkJSON(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	sink := newDiagSink(&stdout, &stderr, diag.FormatOptions{Color: colors.Never}, true)
+
+	sink.Errorf(diag.Message("", "oops"))
+	assert.Equal(t, "", stdout.String())
+
+	var d diag.JSONDiag
+	assert.NoError(t, json.Unmarshal(stderr.Bytes(), &d))
+	assert.Equal(t, diag.JSONDiag{Severity: diag.Error, Message: "oops"}, d)
+}
+
+func TestNewDiagSinkWarningsAsErrors(t *testing.T) {
+	defer func(old int32) { promotedWarnings = old }(promotedWarnings)
+	promotedWarnings = 0
+
+	var stdout, stderr bytes.Buffer
+	sink := newDiagSink(&stdout, &stderr, diag.FormatOptions{Color: colors.Never}, false)
+	sink.Warningf(diag.Message("", "just a warning"))
+	assert.Equal(t, "warning: just a warning\n", stderr.String())
+	assert.False(t, warningsPromoted())
+
+	stderr.Reset()
+	sink = newDiagSink(&stdout, &stderr, diag.FormatOptions{Color: colors.Never, WarningsAsErrors: true}, false)
+	sink.Logf(diag.Warning, diag.Message("", "now an error"))
+	assert.Equal(t, "error: now an error\n", stderr.String())
+	assert.True(t, warningsPromoted())
+}
+
+// captureGlobalDiag resets the global diagnostics sink and flags, redirecting stderr to a temporary file.  It returns
+// a function that reads back everything written to stderr and a function that restores the previous globals.
+func captureGlobalDiag(t *testing.T) (func() []byte, func()) {
+	f, err := ioutil.TempFile("", "diag")
+	assert.NoError(t, err)
+
+	oldSnk, oldStderr := snk, os.Stderr
+	oldJSON, oldWarningsAsErrors, oldPromoted := JSONDiagnostics, WarningsAsErrors, promotedWarnings
+	snk, os.Stderr = nil, f
+	promotedWarnings = 0
+
+	read := func() []byte {
+		b, err := ioutil.ReadFile(f.Name())
+		assert.NoError(t, err)
+		return b
+	}
+	restore := func() {
+		snk, os.Stderr = oldSnk, oldStderr
+		JSONDiagnostics, WarningsAsErrors, promotedWarnings = oldJSON, oldWarningsAsErrors, oldPromoted
+		contract.IgnoreClose(f)
+		contract.IgnoreError(os.Remove(f.Name()))
+	}
+	return read, restore
+}
+
+// TestDiagJSONDiagnostics ensures that setting JSONDiagnostics makes Diag build a JSON sink on stderr.
+func TestDiagJSONDiagnostics(t *testing.T) {
+	read, restore := captureGlobalDiag(t)
+	defer restore()
+	JSONDiagnostics = true
+
+	Diag().Errorf(diag.Message("", "oops"))
+
+	var d diag.JSONDiag
+	assert.NoError(t, json.Unmarshal(read(), &d))
+	assert.Equal(t, diag.JSONDiag{Severity: diag.Error, Message: "oops"}, d)
+}
+
+// TestInitDiagWarningsAsErrors ensures that setting WarningsAsErrors makes InitDiag build a sink that promotes warnings
+// and records that it did so.
+func TestInitDiagWarningsAsErrors(t *testing.T) {
+	read, restore := captureGlobalDiag(t)
+	defer restore()
+	JSONDiagnostics = true
+	WarningsAsErrors = true
+
+	InitDiag(diag.FormatOptions{Color: colors.Never})
+	Diag().Warningf(diag.Message("", "careful"))
+
+	var d diag.JSONDiag
+	assert.NoError(t, json.Unmarshal(read(), &d))
+	assert.Equal(t, diag.JSONDiag{Severity: diag.Error, Message: "careful"}, d)
+	assert.True(t, warningsPromoted())
+}
@@ -109,7 +109,13 @@ func RunFunc(run func(cmd *cobra.Command, args []string) error) func(*cobra.Comm
 // usage.
 func RunResultFunc(run func(cmd *cobra.Command, args []string) result.Result) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
-		if res := run(cmd, args); res != nil {
+		res := run(cmd, args)
+		if res == nil && warningsPromoted() {
+			// Warnings were reported as errors and have already been printed, so fail without another message.
+			res = result.Bail()
+		}
+
+		if res != nil {
 			// Sadly, the fact that we hard-exit below means that it's up to us to replicate the Cobra post-run
 			// behavior here.
 			if postRunErr := runPostCommandHooks(cmd, args); postRunErr != nil {